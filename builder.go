@@ -0,0 +1,93 @@
+package grayhook
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MessageBuilder turns a logrus entry into a GraylogMessage. Implement your
+// own to redact PII, rename fields, or attach trace IDs pulled from the
+// entry's context before the message is marshalled and sent. hook is passed
+// so a builder can read the hook's hostname, facility, extra fields and
+// LegacyFormat setting.
+type MessageBuilder interface {
+	Build(hook *GraylogHook, entry *logrus.Entry) (*GraylogMessage, error)
+}
+
+// DefaultMessageBuilder is the MessageBuilder used when none is supplied. It
+// populates File/Line from entry.Caller when available, and, when
+// entry.Data["error"] holds an error, pulls it out into "full_message" and,
+// unless LegacyFormat is set, the GELF "_error" additional field (rendered
+// with "%+v", which includes a stack trace for errors created with
+// github.com/pkg/errors).
+type DefaultMessageBuilder struct{}
+
+// NewDefaultMessageBuilder builds a DefaultMessageBuilder.
+func NewDefaultMessageBuilder() *DefaultMessageBuilder {
+	return &DefaultMessageBuilder{}
+}
+
+// Build implements MessageBuilder.
+func (DefaultMessageBuilder) Build(hook *GraylogHook, entry *logrus.Entry) (*GraylogMessage, error) {
+	fields := make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		fields[k] = v
+	}
+
+	full, hasErr := extractError(fields)
+
+	var msg *GraylogMessage
+	if hook.legacyFormat {
+		msg = &GraylogMessage{
+			Version:   "1.0",
+			Host:      hook.hostname,
+			Short:     entry.Message,
+			Full:      full,
+			TimeUnix:  entry.Time,
+			Level:     setLevel(entry.Level),
+			Facility:  hook.facility,
+			LogFields: fields,
+			Extra:     hook.extra,
+		}
+	} else {
+		additional := additionalFields(fields, hook.extra)
+		if hasErr {
+			additional["_error"] = full
+		}
+		msg = &GraylogMessage{
+			Version:    "1.1",
+			Host:       hook.hostname,
+			Short:      entry.Message,
+			Full:       full,
+			TimeUnix:   float64(entry.Time.UnixNano()) / float64(1e9),
+			Level:      gelfSeverity(entry.Level),
+			Facility:   hook.facility,
+			Additional: additional,
+		}
+	}
+
+	if entry.HasCaller() {
+		msg.File = entry.Caller.File
+		msg.Line = entry.Caller.Line
+	}
+
+	return msg, nil
+}
+
+// extractError removes the "error" key from fields, if present and it holds
+// an error, returning its full ("%+v") rendering.
+func extractError(fields logrus.Fields) (full string, found bool) {
+	errVal, ok := fields["error"]
+	if !ok {
+		return "", false
+	}
+
+	err, ok := errVal.(error)
+	if !ok {
+		return "", false
+	}
+
+	delete(fields, "error")
+	return fmt.Sprintf("%+v", err), true
+}
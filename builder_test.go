@@ -0,0 +1,203 @@
+package grayhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestHook(t *testing.T, transport Transport, opts ...Option) (*GraylogHook, *fakeTransport) {
+	t.Helper()
+
+	ft, ok := transport.(*fakeTransport)
+	if !ok {
+		ft = &fakeTransport{}
+	}
+
+	hook, err := NewGraylogHookWithTransport(ft, 1, map[string]interface{}{"env": "test"}, opts...)
+	if err != nil {
+		t.Fatalf("NewGraylogHookWithTransport: %v", err)
+	}
+	return hook, ft
+}
+
+func TestDefaultMessageBuilderBuildWithoutError(t *testing.T) {
+	hook, _ := newTestHook(t, &fakeTransport{})
+
+	entry := &logrus.Entry{
+		Logger:  logrus.New(),
+		Data:    logrus.Fields{"user": "ada"},
+		Message: "hello",
+		Level:   logrus.InfoLevel,
+	}
+
+	msg, err := DefaultMessageBuilder{}.Build(hook, entry)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if msg.Short != "hello" {
+		t.Errorf("Short = %q, want %q", msg.Short, "hello")
+	}
+	if msg.Additional["_user"] != "ada" {
+		t.Errorf("Additional[_user] = %v, want %q", msg.Additional["_user"], "ada")
+	}
+	if msg.Additional["_env"] != "test" {
+		t.Errorf("Additional[_env] = %v, want %q (from hook.extra)", msg.Additional["_env"], "test")
+	}
+	if _, ok := msg.Additional["_error"]; ok {
+		t.Errorf("Additional[_error] present, want none when entry has no error field")
+	}
+	if msg.File != "" || msg.Line != 0 {
+		t.Errorf("File/Line = %q/%d, want empty/0 when entry has no caller", msg.File, msg.Line)
+	}
+}
+
+func TestDefaultMessageBuilderBuildWithError(t *testing.T) {
+	hook, _ := newTestHook(t, &fakeTransport{})
+
+	entry := &logrus.Entry{
+		Logger:  logrus.New(),
+		Data:    logrus.Fields{"error": errors.New("boom")},
+		Message: "failed",
+		Level:   logrus.ErrorLevel,
+	}
+
+	msg, err := DefaultMessageBuilder{}.Build(hook, entry)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if msg.Full != "boom" {
+		t.Errorf("Full = %q, want %q", msg.Full, "boom")
+	}
+	if msg.Additional["_error"] != "boom" {
+		t.Errorf("Additional[_error] = %v, want %q", msg.Additional["_error"], "boom")
+	}
+	if _, ok := msg.Additional["error"]; ok {
+		t.Errorf("the \"error\" key leaked into Additional unprefixed: %v", msg.Additional)
+	}
+}
+
+func TestDefaultMessageBuilderBuildWithCaller(t *testing.T) {
+	hook, _ := newTestHook(t, &fakeTransport{})
+
+	logger := logrus.New()
+	logger.SetReportCaller(true)
+	entry := logger.WithField("k", "v")
+	entry.Message = "hi"
+	entry.Caller = &runtime.Frame{File: "builder_test.go", Line: 90}
+
+	msg, err := DefaultMessageBuilder{}.Build(hook, entry)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if entry.HasCaller() && (msg.File == "" || msg.Line == 0) {
+		t.Errorf("File/Line = %q/%d, want populated when entry.HasCaller()", msg.File, msg.Line)
+	}
+}
+
+func TestDefaultMessageBuilderBuildLegacyFormat(t *testing.T) {
+	hook, _ := newTestHook(t, &fakeTransport{}, WithLegacyFormat(true))
+
+	entry := &logrus.Entry{
+		Logger:  logrus.New(),
+		Data:    logrus.Fields{"user": "ada"},
+		Message: "hello",
+		Level:   logrus.InfoLevel,
+	}
+
+	msg, err := DefaultMessageBuilder{}.Build(hook, entry)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	if msg.Version != "1.0" {
+		t.Errorf("Version = %q, want %q in legacy format", msg.Version, "1.0")
+	}
+	if msg.LogFields["user"] != "ada" {
+		t.Errorf("LogFields[user] = %v, want %q", msg.LogFields["user"], "ada")
+	}
+	if msg.Additional != nil {
+		t.Errorf("Additional = %v, want nil in legacy format", msg.Additional)
+	}
+}
+
+func TestExtractErrorNoErrorField(t *testing.T) {
+	fields := logrus.Fields{"foo": "bar"}
+
+	full, found := extractError(fields)
+	if found {
+		t.Errorf("extractError() found = true, want false")
+	}
+	if full != "" {
+		t.Errorf("extractError() full = %q, want empty", full)
+	}
+	if _, ok := fields["foo"]; !ok {
+		t.Errorf("extractError() removed an unrelated field: %v", fields)
+	}
+}
+
+func TestExtractErrorNonErrorValue(t *testing.T) {
+	fields := logrus.Fields{"error": "not an error value"}
+
+	_, found := extractError(fields)
+	if found {
+		t.Errorf("extractError() found = true, want false when \"error\" isn't an error")
+	}
+	if _, ok := fields["error"]; !ok {
+		t.Errorf("extractError() removed \"error\" even though it wasn't an error value")
+	}
+}
+
+func TestGraylogHookFireDeliversMarshalledMessage(t *testing.T) {
+	hook, ft := newTestHook(t, &fakeTransport{})
+
+	entry := &logrus.Entry{
+		Logger:  logrus.New(),
+		Data:    logrus.Fields{"k": "v"},
+		Message: "fired",
+		Level:   logrus.InfoLevel,
+	}
+
+	if err := hook.Fire(entry); err != nil {
+		t.Fatalf("Fire: %v", err)
+	}
+
+	if err := hook.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if n := ft.count(); n != 1 {
+		t.Fatalf("transport received %d messages, want 1", n)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(ft.sent[0], &got); err != nil {
+		t.Fatalf("unmarshal sent message: %v", err)
+	}
+	if got["short_message"] != "fired" {
+		t.Errorf("short_message = %v, want %q", got["short_message"], "fired")
+	}
+	if got["_k"] != "v" {
+		t.Errorf("_k = %v, want %q", got["_k"], "v")
+	}
+}
+
+func TestGraylogHookFireAfterCloseReturnsErrHookClosed(t *testing.T) {
+	hook, _ := newTestHook(t, &fakeTransport{})
+
+	if err := hook.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	entry := &logrus.Entry{Logger: logrus.New(), Message: "too late"}
+	if err := hook.Fire(entry); err != ErrHookClosed {
+		t.Fatalf("Fire after Close = %v, want ErrHookClosed", err)
+	}
+}
@@ -0,0 +1,268 @@
+package grayhook
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// options collects every constructor-time setting shared by GraylogHook and
+// GraylogSlogHandler. Option funcs mutate it before the graylogClient (and,
+// for GraylogHook, the logrus-specific bits) are built.
+type options struct {
+	workers        int
+	dropOnFull     bool
+	retryPolicy    RetryPolicy
+	metrics        Metrics
+	legacyFormat   bool
+	messageBuilder MessageBuilder
+}
+
+func defaultOptions(retries int) *options {
+	return &options{
+		workers:        1,
+		retryPolicy:    defaultRetryPolicy(retries),
+		metrics:        noopMetrics{},
+		messageBuilder: DefaultMessageBuilder{},
+	}
+}
+
+// Option configures optional behavior shared by NewGraylogHook(WithTransport)
+// and NewGraylogSlogHandler(WithTransport).
+type Option func(*options)
+
+// WithWorkers sets the number of goroutines draining the send queue. The
+// default is 1, matching the hook's historical single-goroutine behavior.
+func WithWorkers(n int) Option {
+	return func(o *options) {
+		if n > 0 {
+			o.workers = n
+		}
+	}
+}
+
+// WithDropOnFull makes delivery non-blocking: once the send queue is full,
+// new entries are dropped and counted instead of blocking the caller. Use
+// Dropped to observe the counter.
+func WithDropOnFull(drop bool) Option {
+	return func(o *options) {
+		o.dropOnFull = drop
+	}
+}
+
+// WithRetryPolicy overrides the default retry policy (exponential backoff
+// with jitter, capped at the retries passed to the constructor).
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithMetrics registers a Metrics implementation to observe the delivery
+// pipeline. See NewPrometheusMetrics for a ready-made Prometheus-backed one.
+func WithMetrics(metrics Metrics) Option {
+	return func(o *options) {
+		o.metrics = metrics
+	}
+}
+
+// WithMessageBuilder overrides the default MessageBuilder used by
+// GraylogHook to turn a logrus entry into a GraylogMessage. It has no
+// effect on GraylogSlogHandler.
+func WithMessageBuilder(builder MessageBuilder) Option {
+	return func(o *options) {
+		o.messageBuilder = builder
+	}
+}
+
+// WithLegacyFormat makes GraylogHook emit the pre-GELF-1.1 message shape
+// (version "1.0", a time.Time timestamp, fields nested under "log_fields"
+// instead of flattened "_"-prefixed top-level fields). It exists so
+// existing deployments can migrate their Graylog input at their own pace.
+// It has no effect on GraylogSlogHandler, which always emits GELF 1.1.
+func WithLegacyFormat(legacy bool) Option {
+	return func(o *options) {
+		o.legacyFormat = legacy
+	}
+}
+
+// graylogClient is the shared delivery pipeline behind both GraylogHook and
+// GraylogSlogHandler: it owns the send queue, the worker pool, the retry
+// policy and the metrics, independent of which logging frontend produced
+// the message.
+type graylogClient struct {
+	transport   Transport
+	buf         chan []byte
+	wg          sync.WaitGroup // tracks in-flight entries, for Flush
+	workersWg   sync.WaitGroup // tracks running worker goroutines, for Close
+	workers     int
+	dropOnFull  bool
+	dropped     uint64
+	retryPolicy RetryPolicy
+	metrics     Metrics
+	ctx         context.Context // cancelled by Close to unblock in-flight sends
+	cancel      context.CancelFunc
+	mu          sync.Mutex // guards closed and the buf-close transition, so enqueue can never send on a closed buf
+	closed      bool       // true once Close has been called, guards enqueue
+}
+
+func newGraylogClient(transport Transport, o *options) *graylogClient {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := &graylogClient{
+		transport:   transport,
+		buf:         make(chan []byte, BufSize),
+		workers:     o.workers,
+		dropOnFull:  o.dropOnFull,
+		retryPolicy: o.retryPolicy,
+		metrics:     o.metrics,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+
+	c.workersWg.Add(c.workers)
+	for i := 0; i < c.workers; i++ {
+		go c.fire() // Log in background
+	}
+
+	return c
+}
+
+// enqueue marshals payload onto the send queue, respecting dropOnFull and
+// rejecting new entries once Close has been called. The closed-check and the
+// channel send happen under mu, the same lock Close holds while closing buf,
+// so a send can never race a close of buf.
+func (c *graylogClient) enqueue(messageBytes []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrHookClosed
+	}
+
+	if c.dropOnFull {
+		c.wg.Add(1)
+		select {
+		case c.buf <- messageBytes:
+		default:
+			c.wg.Done()
+			atomic.AddUint64(&c.dropped, 1)
+			c.metrics.Dropped()
+			return nil
+		}
+		c.metrics.Enqueued()
+		c.metrics.BufferDepth(len(c.buf))
+		return nil
+	}
+
+	c.wg.Add(1)
+	select {
+	case c.buf <- messageBytes:
+	case <-c.ctx.Done():
+		c.wg.Done()
+		return c.ctx.Err()
+	}
+	c.metrics.Enqueued()
+	c.metrics.BufferDepth(len(c.buf))
+
+	return nil
+}
+
+func (c *graylogClient) sendEntry(messageBytes []byte) {
+	defer c.wg.Done()
+
+	start := time.Now()
+	for attempt := 0; ; attempt++ {
+		attemptStart := time.Now()
+		err := c.transport.Send(c.ctx, messageBytes)
+		c.metrics.ObserveLatency(time.Since(attemptStart))
+
+		if err == nil {
+			c.metrics.Sent()
+			return
+		}
+
+		wait, ok := c.retryPolicy.Backoff(attempt, time.Since(start), err)
+		if !ok {
+			return
+		}
+		c.metrics.Retried()
+
+		select {
+		case <-time.After(wait):
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// fire will loop on the 'buf' channel, and write entries to graylog, until
+// buf is closed and drained by Close.
+func (c *graylogClient) fire() {
+	defer c.workersWg.Done()
+
+	for messageBytes := range c.buf {
+		c.metrics.BufferDepth(len(c.buf))
+		c.sendEntry(messageBytes)
+	}
+}
+
+// Dropped returns the number of entries dropped because the send queue was
+// full. Only incremented when built with WithDropOnFull(true).
+func (c *graylogClient) Dropped() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// Flush waits until all enqueued entries have been sent.
+func (c *graylogClient) Flush() {
+	c.wg.Wait()
+}
+
+// Close stops the client from accepting new entries, drains whatever is
+// left in the queue and shuts down the worker goroutines. It blocks until
+// the queue is drained or ctx is done, whichever comes first; in the latter
+// case, in-flight sends are cancelled and Close returns ctx.Err(). The
+// closed flag is flipped and buf is closed under the same mu that enqueue
+// holds while checking closed and sending, so enqueue can never send on buf
+// after it's been closed here.
+func (c *graylogClient) Close(ctx context.Context) error {
+	// An enqueue blocked on a full buf holds mu until c.ctx is cancelled, so
+	// cancel it if ctx is done before mu is acquired.
+	acquired := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.cancel()
+		case <-acquired:
+		}
+	}()
+
+	c.mu.Lock()
+	close(acquired)
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.buf)
+	c.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.workersWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		if c.ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return nil
+	case <-ctx.Done():
+		c.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
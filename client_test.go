@@ -0,0 +1,131 @@
+package grayhook
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTransport records every payload it receives. Send optionally blocks
+// until unblock is closed, to simulate a stalled Graylog endpoint.
+type fakeTransport struct {
+	mu      sync.Mutex
+	sent    [][]byte
+	unblock chan struct{}
+	sendErr error
+}
+
+func (t *fakeTransport) Send(ctx context.Context, payload []byte) error {
+	if t.unblock != nil {
+		select {
+		case <-t.unblock:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	t.mu.Lock()
+	t.sent = append(t.sent, payload)
+	t.mu.Unlock()
+
+	return t.sendErr
+}
+
+func (t *fakeTransport) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.sent)
+}
+
+func TestGraylogClientCloseDrains(t *testing.T) {
+	transport := &fakeTransport{}
+	o := defaultOptions(1)
+	c := newGraylogClient(transport, o)
+
+	for i := 0; i < 5; i++ {
+		if err := c.enqueue([]byte("msg")); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := transport.count(); got != 5 {
+		t.Fatalf("transport.count() = %d, want 5", got)
+	}
+}
+
+func TestGraylogClientEnqueueAfterCloseNeverPanics(t *testing.T) {
+	transport := &fakeTransport{}
+	o := defaultOptions(1)
+	c := newGraylogClient(transport, o)
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if err := c.enqueue([]byte("msg")); err != ErrHookClosed {
+		t.Fatalf("enqueue after Close = %v, want ErrHookClosed", err)
+	}
+}
+
+// TestGraylogClientConcurrentEnqueueAndClose races enqueue against Close to
+// make sure a send can never land on a closed buf (run with -race).
+func TestGraylogClientConcurrentEnqueueAndClose(t *testing.T) {
+	transport := &fakeTransport{}
+	o := defaultOptions(1)
+	c := newGraylogClient(transport, o)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = c.enqueue([]byte("msg"))
+		}()
+	}
+
+	_ = c.Close(context.Background())
+	wg.Wait()
+}
+
+func TestGraylogClientDropOnFull(t *testing.T) {
+	transport := &fakeTransport{unblock: make(chan struct{})}
+	o := defaultOptions(1)
+	o.dropOnFull = true
+	o.workers = 1
+	c := newGraylogClient(transport, o)
+	defer close(transport.unblock)
+
+	// The one worker blocks on its first send, so buf (capacity BufSize)
+	// fills behind it; one more entry than that overflows the queue.
+	for i := 0; i < int(BufSize)+2; i++ {
+		if err := c.enqueue([]byte("msg")); err != nil {
+			t.Fatalf("enqueue: %v", err)
+		}
+	}
+
+	if c.Dropped() == 0 {
+		t.Fatalf("Dropped() = 0, want at least one dropped entry after overfilling the queue")
+	}
+}
+
+func TestGraylogClientCloseRespectsDeadline(t *testing.T) {
+	transport := &fakeTransport{unblock: make(chan struct{})} // never unblocked
+	o := defaultOptions(1)
+	c := newGraylogClient(transport, o)
+
+	if err := c.enqueue([]byte("msg")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := c.Close(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Close() = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,108 @@
+package grayhook
+
+import (
+	"encoding/json"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GraylogMessage is a single GELF message. When built via Fire in the
+// default (non-legacy) format, Additional holds every entry.Data / hook
+// extra field already flattened and "_"-prefixed per the GELF 1.1 spec, and
+// is merged into the marshalled JSON at the top level by MarshalJSON.
+//
+// TimeUnix is a time.Time in LegacyFormat (non-compliant, kept only for
+// backwards compatibility) and a float64 of UNIX seconds otherwise.
+type GraylogMessage struct {
+	Version    string                 `json:"version,omitempty"`
+	Host       string                 `json:"host,omitempty"`
+	Short      string                 `json:"short_message,omitempty"`
+	Full       string                 `json:"full_message,omitempty"`
+	TimeUnix   interface{}            `json:"timestamp,omitempty"`
+	Level      int32                  `json:"level,omitempty"`
+	Facility   string                 `json:"facility,omitempty"`
+	File       string                 `json:"file,omitempty"`
+	Line       int                    `json:"line,omitempty"`
+	Extra      map[string]interface{} `json:"extra,omitempty"`      // LegacyFormat only
+	LogFields  map[string]interface{} `json:"log_fields,omitempty"` // LegacyFormat only
+	Additional map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON implements json.Marshaler, merging Additional into the
+// message's top-level JSON object as required by GELF 1.1.
+func (m *GraylogMessage) MarshalJSON() ([]byte, error) {
+	type alias GraylogMessage
+
+	base, err := json.Marshal((*alias)(m))
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Additional) == 0 {
+		return base, nil
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(base, &out); err != nil {
+		return nil, err
+	}
+	for k, v := range m.Additional {
+		out[k] = v
+	}
+
+	return json.Marshal(out)
+}
+
+// gelfSeverity maps a logrus level to the syslog numeric severity Graylog
+// expects in the GELF "level" field.
+func gelfSeverity(level logrus.Level) int32 {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	case logrus.DebugLevel, logrus.TraceLevel:
+		return 7
+	default:
+		return 6
+	}
+}
+
+// additionalFields flattens and "_"-prefixes one or more field sets (e.g.
+// entry.Data, hook.extra) into GELF 1.1 additional fields, dropping the
+// reserved "id" name and flattening nested maps with dotted keys.
+func additionalFields(fieldSets ...map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for _, fields := range fieldSets {
+		flattenInto("", fields, flat)
+	}
+
+	out := make(map[string]interface{}, len(flat))
+	for k, v := range flat {
+		if k == "id" {
+			continue
+		}
+		out["_"+k] = v
+	}
+
+	return out
+}
+
+func flattenInto(prefix string, fields map[string]interface{}, out map[string]interface{}) {
+	for k, v := range fields {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		if nested, ok := v.(map[string]interface{}); ok {
+			flattenInto(key, nested, out)
+			continue
+		}
+
+		out[key] = v
+	}
+}
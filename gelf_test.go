@@ -0,0 +1,87 @@
+package grayhook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAdditionalFieldsFlattensNestedMaps(t *testing.T) {
+	fields := map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":   42,
+			"name": "ada",
+		},
+		"status": "ok",
+	}
+
+	got := additionalFields(fields)
+
+	want := map[string]interface{}{
+		"_user.id":   42,
+		"_user.name": "ada",
+		"_status":    "ok",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("got[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("additionalFields() = %v, want exactly %v", got, want)
+	}
+}
+
+func TestAdditionalFieldsDropsReservedID(t *testing.T) {
+	fields := map[string]interface{}{
+		"id":   "should-be-dropped",
+		"name": "kept",
+	}
+
+	got := additionalFields(fields)
+
+	if _, ok := got["_id"]; ok {
+		t.Errorf("additionalFields() kept the reserved \"id\" field: %v", got)
+	}
+	if got["_name"] != "kept" {
+		t.Errorf("additionalFields()[\"_name\"] = %v, want %q", got["_name"], "kept")
+	}
+}
+
+func TestAdditionalFieldsMergesMultipleFieldSets(t *testing.T) {
+	entryData := map[string]interface{}{"a": 1}
+	extra := map[string]interface{}{"b": 2}
+
+	got := additionalFields(entryData, extra)
+
+	if got["_a"] != 1 || got["_b"] != 2 {
+		t.Errorf("additionalFields(entryData, extra) = %v, want both field sets merged", got)
+	}
+}
+
+func TestGraylogMessageMarshalJSONMergesAdditional(t *testing.T) {
+	msg := &GraylogMessage{
+		Version: "1.1",
+		Host:    "localhost",
+		Short:   "hello",
+		Additional: map[string]interface{}{
+			"_foo": "bar",
+		},
+	}
+
+	b, err := msg.MarshalJSON()
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+
+	if out["_foo"] != "bar" {
+		t.Errorf("out[\"_foo\"] = %v, want %q", out["_foo"], "bar")
+	}
+	if out["short_message"] != "hello" {
+		t.Errorf("out[\"short_message\"] = %v, want %q", out["short_message"], "hello")
+	}
+}
@@ -1,54 +1,45 @@
 package grayhook
 
 import (
-	"bytes"
-	"crypto/tls"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"os"
 	"path"
-	"sync"
-	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+// ErrHookClosed is returned once Close has been called.
+var ErrHookClosed = errors.New("grayhook: hook is closed")
+
 // BufSize = <value> set before calling NewGraylogHook
 // Once the buffer is full, logging will start blocking, waiting for slots to
 // be available in the queue.
 var BufSize uint = 8192
 
-// GraylogMessage ...
-type GraylogMessage struct {
-	Version   string                 `json:"version,omitempty"`
-	Host      string                 `json:"host,omitempty"`
-	Short     string                 `json:"short_message,omitempty"`
-	Full      string                 `json:"full_message,omitempty"`
-	TimeUnix  time.Time              `json:"timestamp,omitempty"`
-	Level     int32                  `json:"level,omitempty"`
-	Facility  string                 `json:"facility,omitempty"`
-	File      string                 `json:"file,omitempty"`
-	Line      int                    `json:"line,omitempty"`
-	Extra     map[string]interface{} `json:"extra,omitempty"`
-	LogFields map[string]interface{} `json:"log_fields,omitempty"`
-}
-
 // GraylogHook is a writer for graylog
 type GraylogHook struct {
-	graylogAddress string                 // "http://graylog.sdc.com:12201/gelf"
+	*graylogClient
 	hostname       string                 // getting by os.Hostname
 	facility       string                 // getting by os.Hostname
 	extra          map[string]interface{} // will add always
-	retries        int                    // number of retry pos (every 10 second)
-	buf            chan []byte            // chan for send
-	wg             sync.WaitGroup         // wait group for graceful shutdown
-	httpClient     *http.Client           // client for post
+	legacyFormat   bool                   // emit the pre-GELF-1.1 message shape instead
+	messageBuilder MessageBuilder         // turns a logrus entry into a GraylogMessage
 	Level          logrus.Level
 }
 
-// NewGraylogHook creates a Writer
-func NewGraylogHook(graylogAddress string, retries int, extra map[string]interface{}, httpClient *http.Client) (*GraylogHook, error) {
+// NewGraylogHook creates a Writer that delivers GELF messages over HTTP, as
+// before. It is a thin wrapper around NewGraylogHookWithTransport for
+// backwards compatibility; use NewGraylogHookWithTransport directly to pick
+// UDP or TCP instead.
+func NewGraylogHook(graylogAddress string, retries int, extra map[string]interface{}, httpClient *http.Client, opts ...Option) (*GraylogHook, error) {
+	return NewGraylogHookWithTransport(NewHTTPTransport(graylogAddress, httpClient), retries, extra, opts...)
+}
+
+// NewGraylogHookWithTransport creates a Writer that delivers GELF messages
+// over the given Transport (HTTPTransport, UDPTransport or TCPTransport).
+func NewGraylogHookWithTransport(transport Transport, retries int, extra map[string]interface{}, opts ...Option) (*GraylogHook, error) {
 	host, err := os.Hostname()
 	if err != nil {
 		return nil, err
@@ -56,70 +47,29 @@ func NewGraylogHook(graylogAddress string, retries int, extra map[string]interfa
 
 	facility := path.Base(os.Args[0])
 
-	if httpClient == nil {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		httpClient = &http.Client{Transport: tr}
+	o := defaultOptions(retries)
+	for _, opt := range opts {
+		opt(o)
 	}
 
 	hook := &GraylogHook{
-		graylogAddress: graylogAddress,
+		graylogClient:  newGraylogClient(transport, o),
 		hostname:       host,
 		facility:       facility,
 		extra:          extra,
-		retries:        retries,
-		buf:            make(chan []byte, BufSize),
-		httpClient:     httpClient,
+		legacyFormat:   o.legacyFormat,
+		messageBuilder: o.messageBuilder,
 		Level:          logrus.DebugLevel,
 	}
 
-	go hook.fire() // Log in background
-
 	return hook, nil
 }
 
-func (hook *GraylogHook) sendEntry(messageBytes []byte) {
-	defer hook.wg.Done()
-
-	for i := 0; i < hook.retries; i++ {
-		reqPost, err := http.NewRequest("POST", hook.graylogAddress, bytes.NewBuffer(messageBytes))
-		if err != nil {
-			time.Sleep(10 * time.Second)
-			continue
-		}
-
-		respPost, err := hook.httpClient.Do(reqPost)
-		if err != nil {
-			time.Sleep(10 * time.Second)
-			continue
-		}
-		defer respPost.Body.Close()
-		break
-	}
-}
-
-// fire will loop on the 'buf' channel, and write entries to graylog
-func (hook *GraylogHook) fire() {
-	for {
-		messageBytes := <-hook.buf // receive new messageBytes on channel
-		hook.sendEntry(messageBytes)
-	}
-}
-
 //Fire is invoked each time a log is thrown
 func (hook *GraylogHook) Fire(entry *logrus.Entry) error {
-	fmt.Println(entry.Data)
-	grMessage := &GraylogMessage{
-		Version: "1.0",
-		Host:    hook.hostname,
-		Short:   entry.Message,
-		// Full:     entry.Data,
-		TimeUnix:  time.Now(),
-		Level:     setLevel(entry.Level),
-		Facility:  hook.facility,
-		LogFields: entry.Data,
-		Extra:     hook.extra,
+	grMessage, err := hook.messageBuilder.Build(hook, entry)
+	if err != nil {
+		return err
 	}
 
 	messageBytes, err := json.Marshal(grMessage)
@@ -127,15 +77,7 @@ func (hook *GraylogHook) Fire(entry *logrus.Entry) error {
 		return err
 	}
 
-	hook.wg.Add(1)
-	hook.buf <- messageBytes
-
-	return nil
-}
-
-// Flush - wait until all logs has been send
-func (hook *GraylogHook) Flush() {
-	hook.wg.Wait()
+	return hook.enqueue(messageBytes)
 }
 
 // Levels returns the available logging levels.
@@ -0,0 +1,116 @@
+package grayhook
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics observes the delivery pipeline. Implement it yourself to plug the
+// hook into a metrics system other than Prometheus.
+type Metrics interface {
+	Enqueued()
+	Sent()
+	Dropped()
+	Retried()
+	BufferDepth(n int)
+	ObserveLatency(d time.Duration)
+}
+
+// noopMetrics is the default Metrics used when none is supplied.
+type noopMetrics struct{}
+
+func (noopMetrics) Enqueued()                      {}
+func (noopMetrics) Sent()                          {}
+func (noopMetrics) Dropped()                       {}
+func (noopMetrics) Retried()                       {}
+func (noopMetrics) BufferDepth(n int)              {}
+func (noopMetrics) ObserveLatency(d time.Duration) {}
+
+// PrometheusMetrics is a Metrics implementation backed by Prometheus
+// collectors. It implements prometheus.Collector itself, so it can be
+// passed straight to prometheus.MustRegister.
+type PrometheusMetrics struct {
+	enqueued    prometheus.Counter
+	sent        prometheus.Counter
+	dropped     prometheus.Counter
+	retried     prometheus.Counter
+	bufferDepth prometheus.Gauge
+	sendLatency prometheus.Histogram
+}
+
+// NewPrometheusMetrics builds a PrometheusMetrics with all collectors under
+// the given namespace (e.g. "myapp_graylog").
+func NewPrometheusMetrics(namespace string) *PrometheusMetrics {
+	return &PrometheusMetrics{
+		enqueued: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_enqueued_total",
+			Help:      "Total number of log entries enqueued for delivery to Graylog.",
+		}),
+		sent: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_sent_total",
+			Help:      "Total number of log entries successfully delivered to Graylog.",
+		}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_dropped_total",
+			Help:      "Total number of log entries dropped because the send queue was full.",
+		}),
+		retried: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_retried_total",
+			Help:      "Total number of delivery attempts that were retried.",
+		}),
+		bufferDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "buffer_depth",
+			Help:      "Number of entries currently queued for delivery.",
+		}),
+		sendLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "send_latency_seconds",
+			Help:      "Latency of a single delivery attempt to Graylog.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+}
+
+// Enqueued implements Metrics.
+func (m *PrometheusMetrics) Enqueued() { m.enqueued.Inc() }
+
+// Sent implements Metrics.
+func (m *PrometheusMetrics) Sent() { m.sent.Inc() }
+
+// Dropped implements Metrics.
+func (m *PrometheusMetrics) Dropped() { m.dropped.Inc() }
+
+// Retried implements Metrics.
+func (m *PrometheusMetrics) Retried() { m.retried.Inc() }
+
+// BufferDepth implements Metrics.
+func (m *PrometheusMetrics) BufferDepth(n int) { m.bufferDepth.Set(float64(n)) }
+
+// ObserveLatency implements Metrics.
+func (m *PrometheusMetrics) ObserveLatency(d time.Duration) { m.sendLatency.Observe(d.Seconds()) }
+
+// Describe implements prometheus.Collector.
+func (m *PrometheusMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.enqueued.Describe(ch)
+	m.sent.Describe(ch)
+	m.dropped.Describe(ch)
+	m.retried.Describe(ch)
+	m.bufferDepth.Describe(ch)
+	m.sendLatency.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (m *PrometheusMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.enqueued.Collect(ch)
+	m.sent.Collect(ch)
+	m.dropped.Collect(ch)
+	m.retried.Collect(ch)
+	m.bufferDepth.Collect(ch)
+	m.sendLatency.Collect(ch)
+}
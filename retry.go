@@ -0,0 +1,124 @@
+package grayhook
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPError is returned by HTTPTransport.Send when Graylog responds with a
+// server error. RetryAfter is populated from the response's Retry-After
+// header when present.
+type HTTPError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("grayhook: graylog http input returned status %d", e.StatusCode)
+}
+
+// parseRetryAfter parses an HTTP Retry-After header, which is either a
+// number of seconds or an HTTP-date. It returns zero if the header is
+// absent or unparseable.
+func parseRetryAfter(h http.Header) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if when, err := http.ParseTime(v); err == nil {
+		if d := time.Until(when); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// RetryPolicy decides whether and how long to wait before retrying a failed
+// send. attempt is the zero-based index of the attempt that just failed (0
+// on the first failure), elapsed is the time since the first attempt.
+type RetryPolicy interface {
+	Backoff(attempt int, elapsed time.Duration, err error) (d time.Duration, ok bool)
+}
+
+// ExponentialBackoffPolicy retries with exponential backoff and jitter,
+// retrying only on 5xx HTTP responses and network errors.
+type ExponentialBackoffPolicy struct {
+	// MaxAttempts caps the total number of attempts (including the first).
+	// Zero means unlimited.
+	MaxAttempts int
+	// MaxElapsedTime caps the total time spent retrying. Zero means
+	// unlimited.
+	MaxElapsedTime  time.Duration
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+}
+
+// defaultRetryPolicy builds the policy used when no RetryPolicy is supplied,
+// capping attempts at maxAttempts to match the hook's historical retries
+// parameter. maxAttempts<=0 is treated as "send once, don't retry" rather
+// than "retry forever" — MaxAttempts==0 on ExponentialBackoffPolicy means
+// unlimited, and callers passing the zero value for retries (e.g. the zero
+// value of an int parameter) should not get unbounded retries against a
+// dead endpoint.
+func defaultRetryPolicy(maxAttempts int) *ExponentialBackoffPolicy {
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	return &ExponentialBackoffPolicy{
+		MaxAttempts:     maxAttempts,
+		InitialInterval: time.Second,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2,
+	}
+}
+
+// Backoff implements RetryPolicy.
+func (p *ExponentialBackoffPolicy) Backoff(attempt int, elapsed time.Duration, err error) (time.Duration, bool) {
+	if !isRetryable(err) {
+		return 0, false
+	}
+	if p.MaxAttempts > 0 && attempt+1 >= p.MaxAttempts {
+		return 0, false
+	}
+	if p.MaxElapsedTime > 0 && elapsed >= p.MaxElapsedTime {
+		return 0, false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.RetryAfter > 0 {
+		return httpErr.RetryAfter, true
+	}
+
+	interval := p.InitialInterval
+	for i := 0; i < attempt; i++ {
+		interval = time.Duration(float64(interval) * p.Multiplier)
+		if p.MaxInterval > 0 && interval > p.MaxInterval {
+			interval = p.MaxInterval
+			break
+		}
+	}
+
+	// Full jitter: pick uniformly between 0 and interval.
+	return time.Duration(rand.Int63n(int64(interval) + 1)), true
+}
+
+// isRetryable reports whether err is worth retrying. HTTPTransport only
+// returns HTTPError for 5xx responses, so that case is always retryable;
+// any other error (dial/write failures from UDPTransport, TCPTransport, or
+// the HTTP client itself) is treated as a network error and is retryable
+// too.
+func isRetryable(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+	return true
+}
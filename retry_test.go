@@ -0,0 +1,91 @@
+package grayhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDefaultRetryPolicyZeroRetriesSendsOnce(t *testing.T) {
+	p := defaultRetryPolicy(0)
+
+	if _, ok := p.Backoff(0, 0, errors.New("boom")); ok {
+		t.Fatalf("Backoff(0, ...) ok = true, want false for retries<=0 (send-once)")
+	}
+}
+
+func TestDefaultRetryPolicyNegativeRetriesSendsOnce(t *testing.T) {
+	p := defaultRetryPolicy(-1)
+
+	if _, ok := p.Backoff(0, 0, errors.New("boom")); ok {
+		t.Fatalf("Backoff(0, ...) ok = true, want false for retries<=0 (send-once)")
+	}
+}
+
+func TestDefaultRetryPolicyPositiveRetriesBounded(t *testing.T) {
+	p := defaultRetryPolicy(3)
+
+	if _, ok := p.Backoff(0, 0, errors.New("boom")); !ok {
+		t.Fatalf("Backoff(0, ...) ok = false, want true with attempts remaining")
+	}
+	if _, ok := p.Backoff(1, 0, errors.New("boom")); !ok {
+		t.Fatalf("Backoff(1, ...) ok = false, want true with attempts remaining")
+	}
+	if _, ok := p.Backoff(2, 0, errors.New("boom")); ok {
+		t.Fatalf("Backoff(2, ...) ok = true, want false once MaxAttempts is reached")
+	}
+}
+
+func TestExponentialBackoffPolicyNonRetryableErr(t *testing.T) {
+	p := defaultRetryPolicy(5)
+
+	err := &HTTPError{StatusCode: 400}
+	if _, ok := p.Backoff(0, 0, err); ok {
+		t.Fatalf("Backoff with a 4xx HTTPError ok = true, want false (not retryable)")
+	}
+}
+
+func TestExponentialBackoffPolicyMaxElapsedTime(t *testing.T) {
+	p := &ExponentialBackoffPolicy{
+		MaxAttempts:     100,
+		MaxElapsedTime:  time.Second,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      2,
+	}
+
+	if _, ok := p.Backoff(0, 2*time.Second, errors.New("boom")); ok {
+		t.Fatalf("Backoff() ok = true, want false once elapsed exceeds MaxElapsedTime")
+	}
+}
+
+func TestExponentialBackoffPolicyJitterBounds(t *testing.T) {
+	p := &ExponentialBackoffPolicy{
+		MaxAttempts:     100,
+		InitialInterval: 10 * time.Millisecond,
+		MaxInterval:     100 * time.Millisecond,
+		Multiplier:      2,
+	}
+
+	for attempt := 0; attempt < 10; attempt++ {
+		d, ok := p.Backoff(attempt, 0, errors.New("boom"))
+		if !ok {
+			t.Fatalf("Backoff(%d, ...) ok = false, want true", attempt)
+		}
+		if d < 0 || d > p.MaxInterval {
+			t.Fatalf("Backoff(%d, ...) = %v, want in [0, %v]", attempt, d, p.MaxInterval)
+		}
+	}
+}
+
+func TestExponentialBackoffPolicyRetryAfterOverridesJitter(t *testing.T) {
+	p := defaultRetryPolicy(5)
+
+	d, ok := p.Backoff(0, 0, &HTTPError{StatusCode: 503, RetryAfter: 7 * time.Second})
+	if !ok {
+		t.Fatalf("Backoff() ok = false, want true")
+	}
+	if d != 7*time.Second {
+		t.Fatalf("Backoff() = %v, want the HTTPError's RetryAfter (7s) unjittered", d)
+	}
+}
@@ -0,0 +1,184 @@
+package grayhook
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"runtime"
+)
+
+// GraylogSlogHandler is a slog.Handler that delivers GELF 1.1 messages
+// through the same transport/worker/retry machinery as GraylogHook, so
+// users get identical batching, metrics and shutdown behavior regardless of
+// which logging frontend they use.
+type GraylogSlogHandler struct {
+	*graylogClient
+	hostname   string
+	facility   string
+	extra      map[string]interface{}
+	level      slog.Leveler
+	baseFields map[string]interface{} // from WithAttrs, already nested under groups
+	groups     []string               // active group stack, from WithGroup
+}
+
+// NewGraylogSlogHandler creates a slog.Handler that delivers GELF messages
+// over HTTP. It is a thin wrapper around NewGraylogSlogHandlerWithTransport
+// for symmetry with NewGraylogHook; use NewGraylogSlogHandlerWithTransport
+// directly to pick UDP or TCP instead.
+func NewGraylogSlogHandler(graylogAddress string, retries int, extra map[string]interface{}, httpClient *http.Client, level slog.Leveler, opts ...Option) (*GraylogSlogHandler, error) {
+	return NewGraylogSlogHandlerWithTransport(NewHTTPTransport(graylogAddress, httpClient), retries, extra, level, opts...)
+}
+
+// NewGraylogSlogHandlerWithTransport creates a slog.Handler that delivers
+// GELF messages over the given Transport. level may be nil, in which case
+// slog.LevelInfo is the minimum enabled level.
+func NewGraylogSlogHandlerWithTransport(transport Transport, retries int, extra map[string]interface{}, level slog.Leveler, opts ...Option) (*GraylogSlogHandler, error) {
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+
+	facility := path.Base(os.Args[0])
+
+	o := defaultOptions(retries)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	handler := &GraylogSlogHandler{
+		graylogClient: newGraylogClient(transport, o),
+		hostname:      host,
+		facility:      facility,
+		extra:         extra,
+		level:         level,
+	}
+
+	return handler, nil
+}
+
+// Enabled implements slog.Handler.
+func (h *GraylogSlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	min := slog.LevelInfo
+	if h.level != nil {
+		min = h.level.Level()
+	}
+	return level >= min
+}
+
+// Handle implements slog.Handler.
+func (h *GraylogSlogHandler) Handle(_ context.Context, record slog.Record) error {
+	fields := cloneNested(h.baseFields)
+	record.Attrs(func(a slog.Attr) bool {
+		addSlogAttr(fields, h.groups, a)
+		return true
+	})
+
+	msg := &GraylogMessage{
+		Version:    "1.1",
+		Host:       h.hostname,
+		Short:      record.Message,
+		TimeUnix:   float64(record.Time.UnixNano()) / float64(1e9),
+		Level:      gelfSlogSeverity(record.Level),
+		Facility:   h.facility,
+		Additional: additionalFields(fields, h.extra),
+	}
+
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		msg.File = frame.File
+		msg.Line = frame.Line
+	}
+
+	messageBytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return h.enqueue(messageBytes)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *GraylogSlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.baseFields = cloneNested(h.baseFields)
+	for _, a := range attrs {
+		addSlogAttr(clone.baseFields, h.groups, a)
+	}
+	return &clone
+}
+
+// WithGroup implements slog.Handler.
+func (h *GraylogSlogHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	clone.groups = append(append([]string{}, h.groups...), name)
+	return &clone
+}
+
+// gelfSlogSeverity maps an slog.Level to the syslog numeric severity
+// Graylog expects in the GELF "level" field, using the same buckets as
+// gelfSeverity for the equivalent logrus levels.
+func gelfSlogSeverity(level slog.Level) int32 {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// addSlogAttr resolves a into dest, nested under groups, flattening
+// slog.KindGroup values the same way. groups may be a handler's shared
+// group-stack slice, which can have spare capacity; path builds a fresh
+// backing array instead of appending to groups directly; appending to a
+// slice with spare capacity would write into a slot other concurrent
+// Handle calls on the same handler may be appending to at the same time.
+func addSlogAttr(dest map[string]interface{}, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+
+	path := append(append([]string{}, groups...), a.Key)
+	if a.Value.Kind() == slog.KindGroup {
+		sub := make(map[string]interface{})
+		for _, ga := range a.Value.Group() {
+			addSlogAttr(sub, nil, ga)
+		}
+		setNested(dest, path, sub)
+		return
+	}
+	setNested(dest, path, a.Value.Any())
+}
+
+func setNested(dest map[string]interface{}, path []string, v interface{}) {
+	if len(path) == 0 {
+		return
+	}
+	if len(path) == 1 {
+		dest[path[0]] = v
+		return
+	}
+
+	sub, ok := dest[path[0]].(map[string]interface{})
+	if !ok {
+		sub = make(map[string]interface{})
+		dest[path[0]] = sub
+	}
+	setNested(sub, path[1:], v)
+}
+
+func cloneNested(fields map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		if nested, ok := v.(map[string]interface{}); ok {
+			out[k] = cloneNested(nested)
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
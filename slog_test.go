@@ -0,0 +1,96 @@
+package grayhook
+
+import (
+	"log/slog"
+	"sync"
+	"testing"
+)
+
+func TestAddSlogAttrNestsUnderGroups(t *testing.T) {
+	dest := make(map[string]interface{})
+
+	addSlogAttr(dest, []string{"req", "user"}, slog.Int("id", 42))
+
+	req, ok := dest["req"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("dest[\"req\"] = %v, want a nested map", dest["req"])
+	}
+	user, ok := req["user"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("req[\"user\"] = %v, want a nested map", req["user"])
+	}
+	if user["id"] != int64(42) {
+		t.Errorf("user[\"id\"] = %v, want 42", user["id"])
+	}
+}
+
+func TestAddSlogAttrFlattensGroupValue(t *testing.T) {
+	dest := make(map[string]interface{})
+
+	group := slog.Group("user", slog.String("name", "ada"), slog.Int("age", 30))
+	addSlogAttr(dest, nil, group)
+
+	user, ok := dest["user"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("dest[\"user\"] = %v, want a nested map", dest["user"])
+	}
+	if user["name"] != "ada" || user["age"] != int64(30) {
+		t.Errorf("dest[\"user\"] = %v, want name=ada age=30", user)
+	}
+}
+
+func TestAddSlogAttrDoesNotMutateSharedGroups(t *testing.T) {
+	groups := make([]string, 2, 4) // spare capacity, like WithGroup leaves behind
+	groups[0] = "a"
+	groups[1] = "b"
+
+	dest1 := make(map[string]interface{})
+	addSlogAttr(dest1, groups, slog.Int("x", 1))
+
+	dest2 := make(map[string]interface{})
+	addSlogAttr(dest2, groups, slog.Int("y", 2))
+
+	if len(groups) != 2 || groups[0] != "a" || groups[1] != "b" {
+		t.Fatalf("addSlogAttr mutated the shared groups slice: %v", groups)
+	}
+}
+
+// TestAddSlogAttrConcurrentWithSharedGroups exercises the fix for the data
+// race where two goroutines appending a.Key to the same groups slice (one
+// with spare capacity) could write into the same backing-array slot. Run
+// with -race.
+func TestAddSlogAttrConcurrentWithSharedGroups(t *testing.T) {
+	groups := make([]string, 0, 8)
+	groups = append(groups, "a", "b", "c")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			dest := make(map[string]interface{})
+			addSlogAttr(dest, groups, slog.Int("v", n))
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestGraylogSlogHandlerWithGroupIsIndependentPerHandler(t *testing.T) {
+	h, err := NewGraylogSlogHandlerWithTransport(&fakeTransport{}, 1, nil, slog.LevelDebug)
+	if err != nil {
+		t.Fatalf("NewGraylogSlogHandlerWithTransport: %v", err)
+	}
+
+	withReq := h.WithGroup("req").(*GraylogSlogHandler)
+	withReqUser := withReq.WithGroup("user").(*GraylogSlogHandler)
+
+	if len(h.groups) != 0 {
+		t.Fatalf("base handler groups = %v, want empty", h.groups)
+	}
+	if got := withReq.groups; len(got) != 1 || got[0] != "req" {
+		t.Fatalf("withReq.groups = %v, want [req]", got)
+	}
+	if got := withReqUser.groups; len(got) != 2 || got[0] != "req" || got[1] != "user" {
+		t.Fatalf("withReqUser.groups = %v, want [req user]", got)
+	}
+}
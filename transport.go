@@ -0,0 +1,247 @@
+package grayhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Compression selects how UDPTransport compresses the GELF payload before
+// chunking it, per the GELF spec.
+type Compression int
+
+const (
+	// CompressionGzip compresses the payload with gzip (the GELF default).
+	CompressionGzip Compression = iota
+	// CompressionZlib compresses the payload with zlib.
+	CompressionZlib
+	// CompressionNone sends the payload uncompressed.
+	CompressionNone
+)
+
+// gelfChunkMagic is the 2-byte magic prefix that marks a GELF UDP chunk.
+var gelfChunkMagic = [2]byte{0x1e, 0x0f}
+
+const (
+	gelfChunkHeaderSize = 12
+	gelfMaxChunkSize    = 8192
+	gelfMaxChunks       = 128
+)
+
+// Transport delivers an already-marshalled GELF payload to Graylog over a
+// specific wire format. Implementations must be safe for concurrent use.
+type Transport interface {
+	Send(ctx context.Context, payload []byte) error
+}
+
+// HTTPTransport posts the GELF payload to a Graylog HTTP input, e.g.
+// "http://graylog.sdc.com:12201/gelf".
+type HTTPTransport struct {
+	Address string
+	Client  *http.Client
+}
+
+// NewHTTPTransport builds an HTTPTransport. If client is nil, a client with
+// InsecureSkipVerify is used, matching the hook's historical default.
+func NewHTTPTransport(address string, client *http.Client) *HTTPTransport {
+	if client == nil {
+		client = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		}
+	}
+	return &HTTPTransport{Address: address, Client: client}
+}
+
+// Send implements Transport.
+func (t *HTTPTransport) Send(ctx context.Context, payload []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.Address, bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return &HTTPError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header),
+		}
+	}
+
+	return nil
+}
+
+// UDPTransport sends the GELF payload as chunked, compressed UDP datagrams,
+// e.g. to "graylog.sdc.com:12201".
+type UDPTransport struct {
+	Address     string
+	Compression Compression
+	// ChunkSize is the max size of a single UDP datagram, including the
+	// 12-byte chunk header. Defaults to gelfMaxChunkSize when zero.
+	ChunkSize int
+}
+
+// NewUDPTransport builds a UDPTransport using the given compression.
+func NewUDPTransport(address string, compression Compression) *UDPTransport {
+	return &UDPTransport{Address: address, Compression: compression}
+}
+
+// Send implements Transport. It compresses payload according to
+// t.Compression, then splits it into GELF chunks if it doesn't fit in a
+// single datagram.
+func (t *UDPTransport) Send(ctx context.Context, payload []byte) error {
+	compressed, err := compress(t.Compression, payload)
+	if err != nil {
+		return err
+	}
+
+	chunkSize := t.ChunkSize
+	if chunkSize <= gelfChunkHeaderSize {
+		chunkSize = gelfMaxChunkSize
+	}
+	dataSize := chunkSize - gelfChunkHeaderSize
+
+	chunks, err := splitChunks(compressed, dataSize)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.Dial("udp", t.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if len(chunks) == 1 {
+		_, err := writeWithContext(ctx, conn, chunks[0])
+		return err
+	}
+
+	var msgID [8]byte
+	if _, err := rand.Read(msgID[:]); err != nil {
+		return err
+	}
+
+	total := len(chunks)
+	for seq, chunk := range chunks {
+		buf := make([]byte, 0, gelfChunkHeaderSize+len(chunk))
+		buf = append(buf, gelfChunkMagic[0], gelfChunkMagic[1])
+		buf = append(buf, msgID[:]...)
+		buf = append(buf, byte(seq), byte(total))
+		buf = append(buf, chunk...)
+
+		if _, err := writeWithContext(ctx, conn, buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func splitChunks(payload []byte, dataSize int) ([][]byte, error) {
+	if len(payload) <= dataSize {
+		return [][]byte{payload}, nil
+	}
+
+	var chunks [][]byte
+	for len(payload) > 0 {
+		n := dataSize
+		if n > len(payload) {
+			n = len(payload)
+		}
+		chunks = append(chunks, payload[:n])
+		payload = payload[n:]
+	}
+
+	if len(chunks) > gelfMaxChunks {
+		return nil, fmt.Errorf("grayhook: message requires %d UDP chunks, max is %d", len(chunks), gelfMaxChunks)
+	}
+
+	return chunks, nil
+}
+
+func compress(c Compression, payload []byte) ([]byte, error) {
+	switch c {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionZlib:
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionNone:
+		return payload, nil
+	default:
+		return nil, fmt.Errorf("grayhook: unknown compression %d", c)
+	}
+}
+
+func writeWithContext(ctx context.Context, w io.Writer, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.Write(p)
+}
+
+// TCPTransport sends the GELF payload as a null-byte delimited JSON message
+// over TCP, optionally wrapped in TLS.
+type TCPTransport struct {
+	Address   string
+	TLSConfig *tls.Config
+}
+
+// NewTCPTransport builds a TCPTransport. If tlsConfig is non-nil, the
+// connection is established over TLS.
+func NewTCPTransport(address string, tlsConfig *tls.Config) *TCPTransport {
+	return &TCPTransport{Address: address, TLSConfig: tlsConfig}
+}
+
+// Send implements Transport.
+func (t *TCPTransport) Send(ctx context.Context, payload []byte) error {
+	var conn net.Conn
+	var err error
+
+	dialer := &net.Dialer{}
+	if t.TLSConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", t.Address, t.TLSConfig)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", t.Address)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	framed := make([]byte, 0, len(payload)+1)
+	framed = append(framed, payload...)
+	framed = append(framed, 0)
+
+	_, err = writeWithContext(ctx, conn, framed)
+	return err
+}
@@ -0,0 +1,218 @@
+package grayhook
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSplitChunksFitsInOneChunk(t *testing.T) {
+	payload := []byte("hello")
+
+	chunks, err := splitChunks(payload, 10)
+	if err != nil {
+		t.Fatalf("splitChunks: %v", err)
+	}
+	if len(chunks) != 1 || !bytes.Equal(chunks[0], payload) {
+		t.Fatalf("splitChunks() = %v, want a single chunk equal to payload", chunks)
+	}
+}
+
+func TestSplitChunksRoundTrip(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 25)
+
+	for _, dataSize := range []int{1, 4, 7, 10, 25, 26} {
+		chunks, err := splitChunks(payload, dataSize)
+		if err != nil {
+			t.Fatalf("splitChunks(dataSize=%d): %v", dataSize, err)
+		}
+
+		var got []byte
+		for _, c := range chunks {
+			if dataSize > 0 && len(c) > dataSize {
+				t.Fatalf("splitChunks(dataSize=%d) produced a chunk of len %d", dataSize, len(c))
+			}
+			got = append(got, c...)
+		}
+		if !bytes.Equal(got, payload) {
+			t.Fatalf("splitChunks(dataSize=%d) round-trip = %q, want %q", dataSize, got, payload)
+		}
+	}
+}
+
+func TestSplitChunksTooManyChunks(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), gelfMaxChunks+1)
+
+	if _, err := splitChunks(payload, 1); err == nil {
+		t.Fatalf("splitChunks() err = nil, want an error once chunk count exceeds gelfMaxChunks")
+	}
+}
+
+func TestCompressGzipRoundTrip(t *testing.T) {
+	payload := []byte(`{"short_message":"hello"}`)
+
+	out, err := compress(CompressionGzip, payload)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read gzip: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("gzip round-trip = %q, want %q", got, payload)
+	}
+}
+
+func TestCompressZlibRoundTrip(t *testing.T) {
+	payload := []byte(`{"short_message":"hello"}`)
+
+	out, err := compress(CompressionZlib, payload)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+
+	r, err := zlib.NewReader(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("zlib.NewReader: %v", err)
+	}
+	defer r.Close()
+
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read zlib: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Fatalf("zlib round-trip = %q, want %q", got, payload)
+	}
+}
+
+func TestCompressNone(t *testing.T) {
+	payload := []byte("hello")
+
+	out, err := compress(CompressionNone, payload)
+	if err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if !bytes.Equal(out, payload) {
+		t.Fatalf("compress(CompressionNone) = %q, want %q unchanged", out, payload)
+	}
+}
+
+// TestUDPTransportSendTinyChunkSizeFallsBack exercises the ChunkSize guard:
+// any value too small to fit the 12-byte chunk header must fall back to
+// gelfMaxChunkSize instead of panicking or looping forever.
+func TestUDPTransportSendTinyChunkSizeFallsBack(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	defer conn.Close()
+
+	for _, size := range []int{1, 5, gelfChunkHeaderSize, gelfChunkHeaderSize + 1} {
+		transport := &UDPTransport{Address: conn.LocalAddr().String(), Compression: CompressionNone, ChunkSize: size}
+
+		done := make(chan error, 1)
+		go func() {
+			done <- transport.Send(context.Background(), []byte("hello"))
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Send(ChunkSize=%d): %v", size, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Send(ChunkSize=%d) did not return, want it to fall back to gelfMaxChunkSize", size)
+		}
+
+		buf := make([]byte, gelfMaxChunkSize)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		if _, _, err := conn.ReadFrom(buf); err != nil {
+			t.Fatalf("ReadFrom after Send(ChunkSize=%d): %v", size, err)
+		}
+	}
+}
+
+// TestUDPTransportSendChunkedReassembly checks the chunk header layout
+// (magic, msg id, seq, total) by reassembling a multi-chunk send the way a
+// real Graylog UDP input would.
+func TestUDPTransportSendChunkedReassembly(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	defer conn.Close()
+
+	payload := bytes.Repeat([]byte("a"), 100)
+	transport := &UDPTransport{
+		Address:     conn.LocalAddr().String(),
+		Compression: CompressionNone,
+		ChunkSize:   gelfChunkHeaderSize + 30, // forces multiple chunks
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- transport.Send(context.Background(), payload)
+	}()
+
+	wantChunks := 4 // 100 bytes / 30 bytes per chunk, rounded up
+	chunks := make(map[byte][]byte)
+	var msgID []byte
+	var total byte
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for i := 0; i < wantChunks; i++ {
+		buf := make([]byte, gelfMaxChunkSize)
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("ReadFrom: %v", err)
+		}
+		buf = buf[:n]
+
+		if buf[0] != gelfChunkMagic[0] || buf[1] != gelfChunkMagic[1] {
+			t.Fatalf("chunk magic = %v, want %v", buf[:2], gelfChunkMagic)
+		}
+		id := buf[2:10]
+		if msgID == nil {
+			msgID = append([]byte{}, id...)
+		} else if !bytes.Equal(msgID, id) {
+			t.Fatalf("chunk msg id = %v, want consistent %v across chunks", id, msgID)
+		}
+
+		seq, tot := buf[10], buf[11]
+		total = tot
+		chunks[seq] = append([]byte{}, buf[12:]...)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if int(total) != wantChunks {
+		t.Fatalf("total = %d, want %d", total, wantChunks)
+	}
+
+	var reassembled []byte
+	for seq := byte(0); seq < total; seq++ {
+		chunk, ok := chunks[seq]
+		if !ok {
+			t.Fatalf("missing chunk seq %d", seq)
+		}
+		reassembled = append(reassembled, chunk...)
+	}
+	if !bytes.Equal(reassembled, payload) {
+		t.Fatalf("reassembled payload = %q, want %q", reassembled, payload)
+	}
+}